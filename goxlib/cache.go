@@ -0,0 +1,313 @@
+package goxlib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+	"time"
+)
+
+// BuildCache is a content-addressed store of previously built binaries,
+// keyed on everything that can affect the output of a build. It lets
+// CrossCompile skip targets that are already up to date in a matrix
+// rebuild.
+type BuildCache struct {
+	Dir     string
+	MaxSize int64 // bytes; 0 means no eviction
+}
+
+// DefaultCacheDir returns $GOX_CACHE if set, otherwise
+// $XDG_CACHE_HOME/gox (falling back to $HOME/.cache/gox).
+func DefaultCacheDir() string {
+	if dir := os.Getenv("GOX_CACHE"); dir != "" {
+		return dir
+	}
+
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "gox")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "gox")
+	}
+
+	return filepath.Join(home, ".cache", "gox")
+}
+
+// CacheKey hashes everything that can change the output binary for opts:
+// the resolved platform, the effective CGO/ldflags/gcflags/tags/Go
+// version, and the source files of the package itself. When deps is
+// non-nil (the normal case — it's the shared analysis result from the
+// scheduler's per-package analyze action), the source files of every
+// transitive dependency are hashed too, so a change anywhere in the
+// import graph invalidates the cache entry rather than just a change in
+// opts.PackagePath itself.
+func CacheKey(opts *CompileOpts, goVersion string, deps *PackageDeps) (string, error) {
+	dirs, err := sourceDirs(opts, deps)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "path=%s\nos=%s\narch=%s\ncgo=%t\nldflags=%s\ngcflags=%s\ntags=%s\ngoversion=%s\n",
+		opts.PackagePath, opts.Platform.OS, opts.Platform.Arch, opts.Cgo,
+		opts.Ldflags, opts.Gcflags, opts.Tags, goVersion)
+
+	for _, dir := range dirs {
+		files, err := sourceFiles(dir)
+		if err != nil {
+			return "", fmt.Errorf("error hashing sources under %s: %s", dir, err)
+		}
+		for _, f := range files {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return "", fmt.Errorf("error hashing %s: %s", f, err)
+			}
+			// Hash the full path, not just the base name: two different
+			// packages in the dependency graph can share a file name.
+			fmt.Fprintf(h, "file=%s\n", f)
+			h.Write(data)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sourceDirs returns the set of package source directories whose files
+// should be hashed for opts. If deps is available it's the package's own
+// directory plus every transitive dependency's directory; otherwise
+// opts.PackagePath is resolved on its own (opts.PackagePath is an import
+// path, e.g. as returned by GoMainDirs — not necessarily a directory on
+// disk, so it must be resolved via `go list` rather than read directly).
+func sourceDirs(opts *CompileOpts, deps *PackageDeps) ([]string, error) {
+	if deps != nil && deps.Dir != "" {
+		dirSet := map[string]bool{deps.Dir: true}
+		for _, dep := range deps.Deps {
+			if dir, ok := deps.DepDirs[dep]; ok {
+				dirSet[dir] = true
+			}
+		}
+
+		dirs := make([]string, 0, len(dirSet))
+		for dir := range dirSet {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+		return dirs, nil
+	}
+
+	dir, err := resolvePackageDir(opts.GoCmd, opts.PackagePath)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving source directory for %s: %s", opts.PackagePath, err)
+	}
+	return []string{dir}, nil
+}
+
+func sourceFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".go" {
+			continue
+		}
+		files = append(files, filepath.Join(dir, e.Name()))
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// outputTemplateData mirrors the fields available to the -output
+// template (see the "Output path template" section of helpText, which
+// is the documented, public contract for what that template can use) so
+// the cache can predict a build's destination path before running it.
+type outputTemplateData struct {
+	Dir  string
+	OS   string
+	Arch string
+}
+
+func renderOutputPath(tpl string, opts *CompileOpts) (string, error) {
+	t, err := template.New("output").Parse(tpl)
+	if err != nil {
+		return "", err
+	}
+
+	data := outputTemplateData{
+		Dir:  filepath.Base(opts.PackagePath),
+		OS:   opts.Platform.OS,
+		Arch: opts.Platform.Arch,
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	out := buf.String()
+	if opts.Platform.OS == "windows" {
+		out += ".exe"
+	}
+
+	return out, nil
+}
+
+// Get hardlinks (or copies, if linking isn't possible) the cached binary
+// for key to dest. It returns false, nil on a cache miss.
+func (c *BuildCache) Get(key, dest string) (bool, error) {
+	src := c.entryPath(key)
+	if _, err := os.Stat(src); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := linkOrCopy(src, dest); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	os.Chtimes(src, now, now)
+	return true, nil
+}
+
+// Put populates the cache entry for key from the binary at src.
+func (c *BuildCache) Put(key, src string) error {
+	dest := c.entryPath(key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	return linkOrCopy(src, dest)
+}
+
+func (c *BuildCache) entryPath(key string) string {
+	return filepath.Join(c.Dir, key[:2], key)
+}
+
+func linkOrCopy(src, dest string) error {
+	os.Remove(dest)
+	if err := os.Link(src, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// buildWithCache wraps GoCrossCompile with a cache lookup: when cache is
+// non-nil and useCache is true, it hashes opts' inputs (including the
+// transitive dependency sources in deps, when available) and serves a
+// cache hit by hardlinking/copying the cached binary to the predicted
+// output path instead of invoking the compiler; on a miss it builds
+// normally and populates the cache for next time.
+func buildWithCache(cache *BuildCache, opts *CompileOpts, goVersion string, useCache bool, deps *PackageDeps) (string, error) {
+	if cache == nil || !useCache {
+		return GoCrossCompile(opts)
+	}
+
+	key, err := CacheKey(opts, goVersion, deps)
+	if err != nil {
+		// Couldn't hash the inputs (e.g. unreadable source); fall back to
+		// a normal, uncached build rather than failing outright.
+		fmt.Fprintf(os.Stderr, "warning: failed to compute cache key for %s: %s; building without cache\n", opts.PackagePath, err)
+		return GoCrossCompile(opts)
+	}
+
+	binName, err := renderOutputPath(opts.OutputTpl, opts)
+	if err != nil {
+		return GoCrossCompile(opts)
+	}
+	dest := filepath.Join(opts.PackagePath, binName)
+
+	if hit, err := cache.Get(key, dest); err == nil && hit {
+		fmt.Printf("--> %15s: %s (cache hit)\n", opts.Platform.String(), opts.PackagePath)
+		return binName, nil
+	}
+
+	binName, err = GoCrossCompile(opts)
+	if err != nil {
+		return binName, err
+	}
+
+	if err := cache.Put(key, filepath.Join(opts.PackagePath, binName)); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to populate build cache: %s\n", err)
+	}
+
+	return binName, nil
+}
+
+// Evict removes the least-recently-used (by mtime) entries until the
+// cache's total size is at or under MaxSize. It is a no-op when MaxSize
+// is not set.
+func (c *BuildCache) Evict() error {
+	if c.MaxSize <= 0 {
+		return nil
+	}
+
+	type cacheFile struct {
+		path  string
+		size  int64
+		mtime time.Time
+	}
+
+	var files []cacheFile
+	var total int64
+	err := filepath.Walk(c.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		files = append(files, cacheFile{path, info.Size(), info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if total <= c.MaxSize {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].mtime.Before(files[j].mtime) })
+	for _, f := range files {
+		if total <= c.MaxSize {
+			break
+		}
+		if err := os.Remove(f.path); err != nil {
+			continue
+		}
+		total -= f.size
+	}
+
+	return nil
+}
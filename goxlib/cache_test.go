@@ -0,0 +1,186 @@
+package goxlib
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheKey_ChangesWithSource(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	opts := &CompileOpts{PackagePath: dir, Platform: Platform{OS: "linux", Arch: "amd64"}}
+	deps := &PackageDeps{Dir: dir}
+	key1, err := CacheKey(opts, "go1.22", deps)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main // changed"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	key2, err := CacheKey(opts, "go1.22", deps)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if key1 == key2 {
+		t.Fatalf("expected cache key to change when source changes")
+	}
+}
+
+func TestCacheKey_ChangesWithDependencySource(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	depDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(depDir, "dep.go"), []byte("package dep"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	opts := &CompileOpts{PackagePath: dir, Platform: Platform{OS: "linux", Arch: "amd64"}}
+	deps := &PackageDeps{
+		Dir:     dir,
+		Deps:    []string{"example.com/dep"},
+		DepDirs: map[string]string{"example.com/dep": depDir},
+	}
+
+	key1, err := CacheKey(opts, "go1.22", deps)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(depDir, "dep.go"), []byte("package dep // changed"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	key2, err := CacheKey(opts, "go1.22", deps)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if key1 == key2 {
+		t.Fatalf("expected cache key to change when a dependency's source changes")
+	}
+}
+
+func TestCacheKey_ResolvesImportPathPackagePath(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/foo\n\ngo 1.21\n"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cmd := exec.Command("go", "list", "-f", "{{.Dir}}", "example.com/foo")
+	cmd.Dir = dir
+	if err := cmd.Run(); err != nil {
+		t.Skipf("go list not usable in this environment: %s", err)
+	}
+
+	opts := &CompileOpts{PackagePath: "example.com/foo", Platform: Platform{OS: "linux", Arch: "amd64"}, GoCmd: "go"}
+
+	// No PackageDeps is supplied (as if the caller built CompileOpts
+	// without going through the scheduler's analyze action): PackagePath
+	// is an import path, not a directory, so CacheKey must resolve it
+	// itself rather than calling os.ReadDir(opts.PackagePath) directly.
+	origWd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Chdir(origWd)
+
+	if _, err := CacheKey(opts, "go1.22", nil); err != nil {
+		t.Fatalf("expected CacheKey to resolve an import-path PackagePath, got error: %s", err)
+	}
+}
+
+func TestBuildCache_GetPut(t *testing.T) {
+	cacheDir := t.TempDir()
+	c := &BuildCache{Dir: cacheDir}
+
+	srcDir := t.TempDir()
+	binPath := filepath.Join(srcDir, "bin")
+	if err := os.WriteFile(binPath, []byte("binary"), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := c.Put("somekey", binPath); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	destPath := filepath.Join(srcDir, "restored")
+	hit, err := c.Get("somekey", destPath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !hit {
+		t.Fatalf("expected a cache hit")
+	}
+
+	data, err := os.ReadFile(destPath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(data) != "binary" {
+		t.Fatalf("got %q, want %q", data, "binary")
+	}
+
+	if _, err := c.Get("missing", destPath); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestBuildCache_Evict(t *testing.T) {
+	cacheDir := t.TempDir()
+	c := &BuildCache{Dir: cacheDir, MaxSize: 5}
+
+	old := filepath.Join(cacheDir, "aa")
+	os.MkdirAll(old, 0755)
+	oldFile := filepath.Join(old, "aaold")
+	os.WriteFile(oldFile, []byte("12345"), 0644)
+	os.Chtimes(oldFile, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+
+	newFile := filepath.Join(old, "aanew")
+	os.WriteFile(newFile, []byte("67890"), 0644)
+
+	if err := c.Evict(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, err := os.Stat(oldFile); !os.IsNotExist(err) {
+		t.Fatalf("expected the oldest entry to be evicted")
+	}
+	if _, err := os.Stat(newFile); err != nil {
+		t.Fatalf("expected the newest entry to survive: %s", err)
+	}
+}
+
+func TestRenderOutputPath(t *testing.T) {
+	opts := &CompileOpts{
+		PackagePath: "/src/example.com/foo",
+		Platform:    Platform{OS: "windows", Arch: "amd64"},
+	}
+
+	got, err := renderOutputPath("{{.Dir}}_{{.OS}}_{{.Arch}}", opts)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if want := "foo_windows_amd64.exe"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
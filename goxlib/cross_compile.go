@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"sync"
 	"errors"
+	"time"
 
 )
 
@@ -17,11 +18,16 @@ var (
  	outputTpl string
  	parallel int
  	platformFlag PlatformFlag
+ 	platformsExpr string
  	tags string
  	verbose bool
  	flagGcflags string
  	flagCgo, flagRebuild, flagListOSArch bool
  	flagGoCmd string
+ 	manifestPath string
+ 	flagHostOnly, flagTargetOnly, flagKeepBootstrap bool
+ 	flagCache, flagNoCache bool
+ 	flagCacheSize int64
 	flags = flag.NewFlagSet("gox", flag.ExitOnError)
 )
 
@@ -32,6 +38,13 @@ var (
 		using a valid value.`)
 )
 
+// Scheduler weights for build actions: a compile ends in a link step, so
+// it's given more weight than a plain dependency-analysis pass.
+const (
+	analyzeActionWeight = 1
+	compileActionWeight = 2
+)
+
 
 func init(){
 
@@ -39,6 +52,7 @@ func init(){
 	flags.Var(platformFlag.ArchFlagValue(), "arch", "arch to build for or skip")
 	flags.Var(platformFlag.OSArchFlagValue(), "osarch", "os/arch pairs to build for or skip")
 	flags.Var(platformFlag.OSFlagValue(), "os", "os to build for or skip")
+	flags.StringVar(&platformsExpr, "platforms", "", "platform selection expression")
 	flags.StringVar(&ldflags, "ldflags", "", "linker flags")
 	flags.StringVar(&tags, "tags", "", "go build tags")
 	flags.StringVar(&outputTpl, "output", "{{.Dir}}_{{.OS}}_{{.Arch}}", "output path")
@@ -50,9 +64,16 @@ func init(){
 	flags.BoolVar(&flagListOSArch, "osarch-list", false, "")
 	flags.StringVar(&flagGcflags, "gcflags", "", "")
 	flags.StringVar(&flagGoCmd, "gocmd", "go", "")
+	flags.StringVar(&manifestPath, "manifest", "", "write a JSON build manifest to this path")
+	flags.BoolVar(&flagHostOnly, "host-only", false, "build and retain only the host bootstrap toolchain")
+	flags.BoolVar(&flagTargetOnly, "target-only", false, "build only target toolchains, reusing a cached host bootstrap")
+	flags.BoolVar(&flagKeepBootstrap, "keep-bootstrap", false, "don't remove the bootstrap toolchain after a -host-only build")
+	flags.BoolVar(&flagCache, "cache", false, "cache built binaries under $GOX_CACHE and reuse them for unchanged targets")
+	flags.BoolVar(&flagNoCache, "no-cache", false, "bypass the build cache even if -cache is set")
+	flags.Int64Var(&flagCacheSize, "cache-size", 1<<30, "maximum size in bytes of $GOX_CACHE before old entries are evicted")
 }
 
-func CrossCompile() ([]string, error) {
+func CrossCompile() (*Manifest, error) {
 
 	if err := flags.Parse(os.Args[1:]); err != nil {
 		return nil, err
@@ -78,7 +99,13 @@ func CrossCompile() ([]string, error) {
 	}
 
 	if buildToolchain {
-		return nil, mainBuildToolchain(parallel, platformFlag, verbose)
+		toolchainOpts := ToolchainOpts{
+			HostOnly:      flagHostOnly,
+			TargetOnly:    flagTargetOnly,
+			KeepBootstrap: flagKeepBootstrap,
+			Packages:      flags.Args(),
+		}
+		return nil, mainBuildToolchain(parallel, platformFlag, verbose, toolchainOpts)
 	}
 
 	if _, err := exec.LookPath(flagGoCmd); err != nil {
@@ -107,70 +134,157 @@ func CrossCompile() ([]string, error) {
 		return nil, fmt.Errorf("Error reading packages: %s", err.Error())
 	}
 
-	// Determine the platforms we're building for
-	platforms := platformFlag.Platforms(SupportedPlatforms(version))
+	// Determine the platforms we're building for. The "-platforms" DSL
+	// takes precedence over "-os"/"-arch"/"-osarch" when given.
+	var platforms []Platform
+	if platformsExpr != "" {
+		ops, err := ParsePlatformExpr(platformsExpr)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing -platforms: %s", err)
+		}
+		platforms = ApplyPlatformOps(ops, SupportedPlatforms(version))
+	} else {
+		platforms = platformFlag.Platforms(SupportedPlatforms(version))
+	}
 	if len(platforms) == 0 {
 		return nil, ErrNoValidPlatforms
 	}
 
-	// Build in parallel!
+	// Set up the build cache, if requested.
+	var cache *BuildCache
+	if flagCache {
+		cache = &BuildCache{Dir: DefaultCacheDir(), MaxSize: flagCacheSize}
+	}
+
+	// Build using an action-graph scheduler: a dependency-analysis action
+	// runs once per package, and the per-platform compiles for that
+	// package depend on it instead of each redoing the analysis.
+	// Compiles are weighted heavier than analysis since they end in a
+	// link step, so the scheduler's budget models real load rather than
+	// just goroutine count.
 	fmt.Printf("Number of parallel builds: %d\n\n", parallel)
-	var errorLock sync.Mutex
-	var wg sync.WaitGroup
-	errors := make([]string, 0)
-	semaphore := make(chan int, parallel)
-	binPaths := make([]string, 0)
 	var mu sync.Mutex
-	for _, platform := range platforms {
-		for _, path := range mainDirs {
-			// Start the goroutine that will do the actual build
-			wg.Add(1)
-			go func(path string, platform Platform) {
-				defer wg.Done()
-				semaphore <- 1
-				fmt.Printf("--> %15s: %s\n", platform.String(), path)
-
-				opts := &CompileOpts{
-					PackagePath: path,
-					Platform:    platform,
-					OutputTpl:   outputTpl,
-					Ldflags:     ldflags,
-					Tags:        tags,
-					Cgo:         flagCgo,
-					Rebuild:     flagRebuild,
-					GoCmd:       flagGoCmd,
+	buildErrors := make([]error, 0)
+	packageDeps := make(map[string]*PackageDeps, len(mainDirs))
+	manifest := &Manifest{Entries: make([]ManifestEntry, 0, len(platforms)*len(mainDirs))}
+
+	scheduler := NewScheduler(parallel * compileActionWeight)
+	for _, path := range mainDirs {
+		path := path
+		analyzeID := "analyze:" + path
+		scheduler.Add(&Action{
+			ID:     analyzeID,
+			Weight: analyzeActionWeight,
+			Run: func() error {
+				deps, err := AnalyzePackage(flagGoCmd, path)
+				if err != nil {
+					return fmt.Errorf("error analyzing %s: %s", path, err)
 				}
 
-				// Determine if we have specific CFLAGS or LDFLAGS for this
-				// GOOS/GOARCH combo and override the defaults if so.
-				envOverride(&opts.Ldflags, platform, "LDFLAGS")
-				envOverride(&opts.Gcflags, platform, "GCFLAGS")
-				var binName string
-				var err error
-				if binName, err = GoCrossCompile(opts); err != nil {
-					errorLock.Lock()
-					defer errorLock.Unlock()
-					errors = append(errors,
-						fmt.Sprintf("%s error: %s", platform.String(), err))
-				}
 				mu.Lock()
-				binPaths = append(binPaths, path + string(os.PathSeparator) + binName)
+				packageDeps[path] = deps
 				mu.Unlock()
-				<-semaphore
-			}(path, platform)
+				return nil
+			},
+		})
+
+		for _, platform := range platforms {
+			platform := platform
+			scheduler.Add(&Action{
+				ID:     fmt.Sprintf("compile:%s:%s", path, platform.String()),
+				Weight: compileActionWeight,
+				Deps:   []string{analyzeID},
+				Run: func() error {
+					opts := &CompileOpts{
+						PackagePath: path,
+						Platform:    platform,
+						OutputTpl:   outputTpl,
+						Ldflags:     ldflags,
+						Gcflags:     flagGcflags,
+						Tags:        tags,
+						Cgo:         flagCgo,
+						Rebuild:     flagRebuild,
+						GoCmd:       flagGoCmd,
+					}
+
+					// Determine if we have specific CFLAGS or LDFLAGS for this
+					// GOOS/GOARCH combo and override the defaults if so.
+					envOverride(&opts.Ldflags, platform, "LDFLAGS")
+					envOverride(&opts.Gcflags, platform, "GCFLAGS")
+
+					// The compile depends on its package's analyze action
+					// (Deps above), but a dependency can fail without
+					// deadlocking its dependents (see Scheduler). When that
+					// happens there's no PackageDeps to build or cache
+					// against, so skip the build rather than falling back to
+					// a per-compile re-resolution, and record the skip as
+					// the real per-target failure it is.
+					mu.Lock()
+					deps := packageDeps[path]
+					mu.Unlock()
+					if deps == nil {
+						err := fmt.Errorf("%s error: dependency analysis for %s failed, skipping build", platform.String(), path)
+						mu.Lock()
+						manifest.Entries = append(manifest.Entries,
+							newManifestEntry(opts, "", version, "", 0, err))
+						buildErrors = append(buildErrors, err)
+						mu.Unlock()
+						return err
+					}
+
+					fmt.Printf("--> %15s: %s\n", platform.String(), path)
+
+					start := time.Now()
+					binName, err := buildWithCache(cache, opts, version, !flagNoCache && !flagRebuild, deps)
+					duration := time.Since(start)
+
+					binPath := ""
+					if binName != "" {
+						binPath = path + string(os.PathSeparator) + binName
+					}
+
+					mu.Lock()
+					manifest.Entries = append(manifest.Entries,
+						newManifestEntry(opts, deps.ImportPath, version, binPath, duration, err))
+					if err != nil {
+						buildErrors = append(buildErrors,
+							fmt.Errorf("%s error: %s", platform.String(), err))
+					}
+					mu.Unlock()
+
+					return err
+				},
+			})
 		}
 	}
-	wg.Wait()
 
-	if len(errors) > 0 {
-		fmt.Fprintf(os.Stderr, "\n%d errors occurred:\n", len(errors))
-		for _, err := range errors {
+	// Per-build failures (including analysis failures, which now skip and
+	// fail their dependent compiles above) are captured into
+	// buildErrors/manifest, so the scheduler's own aggregate error is only
+	// informational here.
+	_ = scheduler.Run()
+
+	if cache != nil {
+		if err := cache.Evict(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to evict stale build cache entries: %s\n", err)
+		}
+	}
+
+	if manifestPath != "" {
+		if err := WriteManifest(manifestPath, manifest); err != nil {
+			return manifest, err
+		}
+	}
+
+	if len(buildErrors) > 0 {
+		fmt.Fprintf(os.Stderr, "\n%d errors occurred:\n", len(buildErrors))
+		for _, err := range buildErrors {
 			fmt.Fprintf(os.Stderr, "--> %s\n", err)
 		}
-		return nil, err
+		return manifest, &MultiError{Errors: buildErrors}
 	}
 
-	return binPaths, nil
+	return manifest, nil
 }
 
 func PrintUsage() {
@@ -189,13 +303,30 @@ Options:
   -arch=""            Space-separated list of architectures to build for
   -build-toolchain    Build cross-compilation toolchain
   -cgo                Sets CGO_ENABLED=1, requires proper C toolchain (advanced)
+  -cache              Cache built binaries under $GOX_CACHE and reuse them
+                       for targets whose inputs haven't changed
+  -cache-size=1073741824
+                       Maximum size in bytes of $GOX_CACHE before old
+                       entries are evicted
   -gcflags=""         Additional '-gcflags' value to pass to go build
+  -host-only          With -build-toolchain, build and retain only the host
+                       bootstrap toolchain
+  -keep-bootstrap     With -build-toolchain -host-only, don't remove the
+                       bootstrap toolchain once the build finishes
   -ldflags=""         Additional '-ldflags' value to pass to go build
+  -manifest=""        Write a JSON build manifest describing each artifact
+  -no-cache           Bypass the build cache even if -cache is set
+  -target-only        With -build-toolchain, build only target toolchains,
+                       reusing a bootstrap built by a prior -host-only run.
+                       Requires a make.bash/make.bat patched to understand
+                       --host-only/--target-only; fails against stock
+                       upstream Go
   -tags=""            Additional '-tags' value to pass to go build
   -os=""              Space-separated list of operating systems to build for
   -osarch=""          Space-separated list of os/arch pairs to build for
   -osarch-list        List supported os/arch pairs for your Go version
   -output="foo"       Output path template. See below for more info
+  -platforms=""       Platform selection expression. See below for more info
   -parallel=-1        Amount of parallelism, defaults to number of CPUs
   -gocmd="go"         Build command, defaults to Go
   -rebuild            Force rebuilding of package that were up to date
@@ -227,6 +358,16 @@ Platforms (OS/Arch):
   built even if the specific os and arch is negated in "-os" and "-arch",
   respectively.
 
+  The "-platforms" flag is an alternative to "-os"/"-arch"/"-osarch" that
+  takes a single left-to-right modification expression, e.g.:
+
+    -platforms '- +linux/amd64 +darwin/arm64 -windows'
+
+  A leading "-" or "-all" clears the default platform set; each following
+  token is "+os", "-os", "+os/arch", or "-os/arch" and adds or removes
+  entries from the working set. When "-platforms" is given, it takes
+  precedence over "-os", "-arch", and "-osarch".
+
 Platform Overrides:
 
   The "-gcflags" and "-ldflags" options can be overridden per-platform
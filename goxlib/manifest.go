@@ -0,0 +1,104 @@
+package goxlib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// ManifestEntry describes the result of building a single package for a
+// single platform.
+type ManifestEntry struct {
+	ImportPath string        `json:"import_path"`
+	OS         string        `json:"os"`
+	Arch       string        `json:"arch"`
+	OutputPath string        `json:"output_path,omitempty"`
+	Size       int64         `json:"size,omitempty"`
+	SHA256     string        `json:"sha256,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	GoVersion  string        `json:"go_version"`
+	Ldflags    string        `json:"ldflags,omitempty"`
+	Gcflags    string        `json:"gcflags,omitempty"`
+	Tags       string        `json:"tags,omitempty"`
+	Success    bool          `json:"success"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// Manifest is the structured record of a CrossCompile run, suitable for
+// consumption by release tooling that needs to sign, upload, or checksum
+// the resulting artifacts.
+type Manifest struct {
+	Entries []ManifestEntry `json:"entries"`
+}
+
+// newManifestEntry builds a ManifestEntry for a completed build, hashing
+// and stat'ing the output binary when the build succeeded. importPath is
+// the canonical import path resolved by `go list` (PackageDeps.ImportPath);
+// opts.PackagePath is only a fallback for when no resolved path is
+// available (e.g. dependency analysis itself failed), since it may be a
+// relative directory or other `go list`-ish argument rather than the
+// canonical path release tooling expects in the manifest.
+func newManifestEntry(opts *CompileOpts, importPath, version string, binPath string, duration time.Duration, buildErr error) ManifestEntry {
+	if importPath == "" {
+		importPath = opts.PackagePath
+	}
+
+	entry := ManifestEntry{
+		ImportPath: importPath,
+		OS:         opts.Platform.OS,
+		Arch:       opts.Platform.Arch,
+		Duration:   duration,
+		GoVersion:  version,
+		Ldflags:    opts.Ldflags,
+		Gcflags:    opts.Gcflags,
+		Tags:       opts.Tags,
+		Success:    buildErr == nil,
+	}
+
+	if buildErr != nil {
+		entry.Error = buildErr.Error()
+		return entry
+	}
+
+	entry.OutputPath = binPath
+	if size, sum, err := hashFile(binPath); err == nil {
+		entry.Size = size
+		entry.SHA256 = sum
+	}
+
+	return entry
+}
+
+func hashFile(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteManifest marshals m as indented JSON and writes it to path.
+func WriteManifest(path string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding manifest: %s", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing manifest to %s: %s", path, err)
+	}
+
+	return nil
+}
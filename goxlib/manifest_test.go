@@ -0,0 +1,88 @@
+package goxlib
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewManifestEntry_Success(t *testing.T) {
+	dir := t.TempDir()
+	binPath := filepath.Join(dir, "bin")
+	if err := ioutil.WriteFile(binPath, []byte("hello"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	opts := &CompileOpts{
+		PackagePath: "example.com/foo",
+		Platform:    Platform{OS: "linux", Arch: "amd64"},
+		Ldflags:     "-s",
+	}
+
+	entry := newManifestEntry(opts, "", "go1.22", binPath, 5*time.Millisecond, nil)
+	if !entry.Success {
+		t.Fatalf("expected success")
+	}
+	if entry.SHA256 == "" {
+		t.Fatalf("expected a sha256 to be populated")
+	}
+	if entry.Size != int64(len("hello")) {
+		t.Fatalf("expected size %d, got %d", len("hello"), entry.Size)
+	}
+	if entry.ImportPath != opts.PackagePath {
+		t.Fatalf("expected ImportPath to fall back to opts.PackagePath, got %q", entry.ImportPath)
+	}
+}
+
+func TestNewManifestEntry_PrefersResolvedImportPath(t *testing.T) {
+	opts := &CompileOpts{
+		PackagePath: "./foo",
+		Platform:    Platform{OS: "linux", Arch: "amd64"},
+	}
+
+	entry := newManifestEntry(opts, "example.com/foo", "go1.22", "", 0, os.ErrNotExist)
+	if entry.ImportPath != "example.com/foo" {
+		t.Fatalf("expected the resolved import path to take precedence over opts.PackagePath, got %q", entry.ImportPath)
+	}
+}
+
+func TestNewManifestEntry_Failure(t *testing.T) {
+	opts := &CompileOpts{
+		PackagePath: "example.com/foo",
+		Platform:    Platform{OS: "linux", Arch: "amd64"},
+	}
+
+	entry := newManifestEntry(opts, "", "go1.22", "", 0, os.ErrNotExist)
+	if entry.Success {
+		t.Fatalf("expected failure")
+	}
+	if entry.Error == "" {
+		t.Fatalf("expected an error message")
+	}
+}
+
+func TestWriteManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.json")
+
+	m := &Manifest{Entries: []ManifestEntry{{ImportPath: "example.com/foo", OS: "linux", Arch: "amd64", Success: true}}}
+	if err := WriteManifest(path, m); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var got Manifest
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(got.Entries) != 1 || got.Entries[0].ImportPath != "example.com/foo" {
+		t.Fatalf("unexpected manifest contents: %#v", got)
+	}
+}
@@ -0,0 +1,33 @@
+package goxlib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MultiError aggregates the errors encountered while building multiple
+// platforms or toolchains in parallel, so that callers driving gox from
+// CI can see (and count) every failure rather than just the last one.
+type MultiError struct {
+	Errors []error
+}
+
+func (e *MultiError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+
+	points := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		points[i] = err.Error()
+	}
+
+	return fmt.Sprintf(
+		"%d errors occurred:\n\t* %s",
+		len(e.Errors), strings.Join(points, "\n\t* "))
+}
+
+// Unwrap exposes the wrapped errors for errors.Is/errors.As.
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
@@ -0,0 +1,24 @@
+package goxlib
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMultiError_Error(t *testing.T) {
+	e := &MultiError{Errors: []error{errors.New("one"), errors.New("two")}}
+	want := "2 errors occurred:\n\t* one\n\t* two"
+	if got := e.Error(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestMultiError_Unwrap(t *testing.T) {
+	inner := []error{errors.New("one"), errors.New("two")}
+	e := &MultiError{Errors: inner}
+
+	sentinel := inner[1]
+	if !errors.Is(e, sentinel) {
+		t.Fatalf("expected errors.Is to find the wrapped sentinel error")
+	}
+}
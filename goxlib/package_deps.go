@@ -0,0 +1,84 @@
+package goxlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// PackageDeps is the resolved dependency graph for a single package, as
+// reported by `go list -deps -json`. It's computed once per package and
+// shared across every platform that package is compiled for, rather than
+// re-resolved on every (package, platform) pair: compiles read Deps and
+// DepDirs to hash the package's full transitive source set for the build
+// cache instead of just its own directory.
+type PackageDeps struct {
+	ImportPath string
+	Dir        string
+	Deps       []string
+
+	// DepDirs maps every import path seen in the `go list -deps` output
+	// (the root package and each transitive dependency) to its source
+	// directory.
+	DepDirs map[string]string
+}
+
+// AnalyzePackage runs `go list -deps -json` for path and returns its
+// resolved dependency graph, including the source directory of the
+// package itself and of every transitive dependency.
+func AnalyzePackage(goCmd, path string) (*PackageDeps, error) {
+	cmd := exec.Command(goCmd, "list", "-deps", "-json", path)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("error analyzing %s: %s\n%s", path, err, stderr.String())
+	}
+
+	// `go list -json` with multiple results emits a stream of
+	// back-to-back JSON objects, not a JSON array. The named packages
+	// (here, just "path") are emitted last, after their dependencies, so
+	// the final object decoded is the root package.
+	dec := json.NewDecoder(&stdout)
+	result := &PackageDeps{DepDirs: make(map[string]string)}
+	for dec.More() {
+		var pkg struct {
+			ImportPath string
+			Dir        string
+			Deps       []string
+		}
+		if err := dec.Decode(&pkg); err != nil {
+			return nil, fmt.Errorf("error parsing `go list` output for %s: %s", path, err)
+		}
+
+		if pkg.Dir != "" {
+			result.DepDirs[pkg.ImportPath] = pkg.Dir
+		}
+		result.ImportPath = pkg.ImportPath
+		result.Dir = pkg.Dir
+		result.Deps = pkg.Deps
+	}
+
+	if result.ImportPath == "" {
+		return nil, fmt.Errorf("`go list -deps -json` returned no packages for %s", path)
+	}
+
+	return result, nil
+}
+
+// resolvePackageDir resolves an import path (or a relative/absolute
+// directory, which go list also accepts) to the package's absolute
+// source directory.
+func resolvePackageDir(goCmd, path string) (string, error) {
+	cmd := exec.Command(goCmd, "list", "-f", "{{.Dir}}", path)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error resolving package directory for %s: %s\n%s", path, err, stderr.String())
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
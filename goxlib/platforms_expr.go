@@ -0,0 +1,136 @@
+package goxlib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlatformOp is a single step in a -platforms expression: add or remove
+// an OS or OS/Arch pair from the working platform set, or clear the set
+// entirely.
+type PlatformOp struct {
+	Clear bool
+	Add   bool
+	OS    string
+	Arch  string
+}
+
+// ParsePlatformExpr tokenizes a -platforms expression such as
+// "- +linux/amd64 +darwin/arm64 -windows" into a sequence of PlatformOps.
+//
+// The first token may be a bare "-" or "-all" to clear the default
+// platform set before any further tokens are applied. Every other token
+// must be prefixed with "+" (add) or "-" (remove) and names either an
+// OS ("+linux") or an OS/Arch pair ("+linux/amd64").
+func ParsePlatformExpr(expr string) ([]PlatformOp, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty -platforms expression")
+	}
+
+	ops := make([]PlatformOp, 0, len(fields))
+	for i, field := range fields {
+		if field == "-" || field == "-all" {
+			if i != 0 {
+				return nil, fmt.Errorf(
+					"%q may only appear as the first token in a -platforms expression", field)
+			}
+			ops = append(ops, PlatformOp{Clear: true})
+			continue
+		}
+
+		if len(field) < 2 || (field[0] != '+' && field[0] != '-') {
+			return nil, fmt.Errorf(
+				"invalid -platforms token %q: must be prefixed with '+' or '-'", field)
+		}
+
+		op := PlatformOp{Add: field[0] == '+'}
+		rest := field[1:]
+		if idx := strings.IndexByte(rest, '/'); idx >= 0 {
+			op.OS, op.Arch = rest[:idx], rest[idx+1:]
+			if op.OS == "" || op.Arch == "" {
+				return nil, fmt.Errorf(
+					"invalid -platforms token %q: os/arch pair must have both sides", field)
+			}
+		} else {
+			op.OS = rest
+			if op.OS == "" {
+				return nil, fmt.Errorf("invalid -platforms token %q: missing os", field)
+			}
+		}
+
+		ops = append(ops, op)
+	}
+
+	return ops, nil
+}
+
+// ApplyPlatformOps runs ops against the candidate platform set "all"
+// (typically SupportedPlatforms(version)) and returns the resulting
+// working set. Unless the first op clears the set, the working set
+// starts as a copy of "all".
+func ApplyPlatformOps(ops []PlatformOp, all []Platform) []Platform {
+	working := make([]Platform, 0, len(all))
+	start := 0
+	if len(ops) > 0 && ops[0].Clear {
+		start = 1
+	} else {
+		working = append(working, all...)
+	}
+
+	for _, op := range ops[start:] {
+		if op.Arch == "" {
+			working = addOrRemoveOS(working, all, op)
+		} else {
+			working = addOrRemoveOSArch(working, op)
+		}
+	}
+
+	return working
+}
+
+func addOrRemoveOS(working, all []Platform, op PlatformOp) []Platform {
+	if op.Add {
+		for _, p := range all {
+			if p.OS == op.OS && !containsPlatform(working, p) {
+				working = append(working, p)
+			}
+		}
+		return working
+	}
+
+	result := working[:0:0]
+	for _, p := range working {
+		if p.OS != op.OS {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func addOrRemoveOSArch(working []Platform, op PlatformOp) []Platform {
+	p := Platform{OS: op.OS, Arch: op.Arch}
+	if op.Add {
+		if containsPlatform(working, p) {
+			return working
+		}
+		return append(working, p)
+	}
+
+	result := working[:0:0]
+	for _, existing := range working {
+		if existing != p {
+			result = append(result, existing)
+		}
+	}
+	return result
+}
+
+func containsPlatform(list []Platform, p Platform) bool {
+	for _, existing := range list {
+		if existing == p {
+			return true
+		}
+	}
+	return false
+}
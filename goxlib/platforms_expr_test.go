@@ -0,0 +1,120 @@
+package goxlib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePlatformExpr(t *testing.T) {
+	cases := []struct {
+		expr string
+		ops  []PlatformOp
+		err  bool
+	}{
+		{
+			expr: "- +linux/amd64 +darwin/arm64 -windows",
+			ops: []PlatformOp{
+				{Clear: true},
+				{Add: true, OS: "linux", Arch: "amd64"},
+				{Add: true, OS: "darwin", Arch: "arm64"},
+				{Add: false, OS: "windows"},
+			},
+		},
+		{
+			expr: "-all +linux",
+			ops: []PlatformOp{
+				{Clear: true},
+				{Add: true, OS: "linux"},
+			},
+		},
+		{
+			expr: "+linux -linux/386",
+			ops: []PlatformOp{
+				{Add: true, OS: "linux"},
+				{Add: false, OS: "linux", Arch: "386"},
+			},
+		},
+		{expr: "", err: true},
+		{expr: "linux", err: true},
+		{expr: "+linux/", err: true},
+		{expr: "+/amd64", err: true},
+		{expr: "+linux -", err: true},
+	}
+
+	for _, tc := range cases {
+		ops, err := ParsePlatformExpr(tc.expr)
+		if (err != nil) != tc.err {
+			t.Fatalf("expr %q: err = %v, want err = %v", tc.expr, err, tc.err)
+		}
+		if tc.err {
+			continue
+		}
+		if !reflect.DeepEqual(ops, tc.ops) {
+			t.Fatalf("expr %q: got %#v, want %#v", tc.expr, ops, tc.ops)
+		}
+	}
+}
+
+func TestApplyPlatformOps(t *testing.T) {
+	all := []Platform{
+		{OS: "linux", Arch: "amd64"},
+		{OS: "linux", Arch: "386"},
+		{OS: "darwin", Arch: "amd64"},
+		{OS: "darwin", Arch: "arm64"},
+		{OS: "windows", Arch: "amd64"},
+	}
+
+	cases := []struct {
+		name string
+		ops  []PlatformOp
+		want []Platform
+	}{
+		{
+			name: "no ops returns all",
+			ops:  nil,
+			want: all,
+		},
+		{
+			name: "clear then add specific pairs",
+			ops: []PlatformOp{
+				{Clear: true},
+				{Add: true, OS: "linux", Arch: "amd64"},
+				{Add: true, OS: "darwin", Arch: "arm64"},
+			},
+			want: []Platform{
+				{OS: "linux", Arch: "amd64"},
+				{OS: "darwin", Arch: "arm64"},
+			},
+		},
+		{
+			name: "remove whole os from defaults",
+			ops: []PlatformOp{
+				{Add: false, OS: "windows"},
+			},
+			want: []Platform{
+				{OS: "linux", Arch: "amd64"},
+				{OS: "linux", Arch: "386"},
+				{OS: "darwin", Arch: "amd64"},
+				{OS: "darwin", Arch: "arm64"},
+			},
+		},
+		{
+			name: "clear then add whole os",
+			ops: []PlatformOp{
+				{Clear: true},
+				{Add: true, OS: "darwin"},
+			},
+			want: []Platform{
+				{OS: "darwin", Arch: "amd64"},
+				{OS: "darwin", Arch: "arm64"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		got := ApplyPlatformOps(tc.ops, all)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %#v, want %#v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package goxlib
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Action is a single node in a Scheduler's build graph: an arbitrary unit
+// of work that can depend on other Actions by ID and that consumes
+// Weight units of the scheduler's parallelism budget while it runs.
+type Action struct {
+	ID     string
+	Weight int
+	Deps   []string
+	Run    func() error
+}
+
+type schedulerNode struct {
+	action    *Action
+	remaining int
+}
+
+// Scheduler runs a DAG of Actions with a worker pool sized by a total
+// weight budget rather than a plain goroutine count, so that callers can
+// give heavier actions (e.g. a link step) more weight than lighter ones
+// (e.g. a dependency analysis pass) without oversubscribing the machine.
+//
+// Callers inject their own admission policy simply by choosing weights;
+// the scheduler itself only ever enforces the budget and the dependency
+// order.
+type Scheduler struct {
+	// Budget is the total weight that may be in flight at once.
+	Budget int
+
+	actions []*Action
+}
+
+// NewScheduler creates a Scheduler with the given total weight budget.
+func NewScheduler(budget int) *Scheduler {
+	if budget <= 0 {
+		budget = 1
+	}
+	return &Scheduler{Budget: budget}
+}
+
+// Add registers an action with the scheduler. It must be called before Run.
+func (s *Scheduler) Add(a *Action) {
+	s.actions = append(s.actions, a)
+}
+
+// Run executes every registered action, respecting Deps and the weight
+// budget, and returns a *MultiError aggregating any action failures. A
+// failed action still unblocks its dependents so the rest of the graph
+// keeps making progress, matching the rest of gox's "report every
+// failure, don't just stop at the first one" behavior.
+func (s *Scheduler) Run() error {
+	nodes := make(map[string]*schedulerNode, len(s.actions))
+	dependents := make(map[string][]string)
+	for _, a := range s.actions {
+		nodes[a.ID] = &schedulerNode{action: a, remaining: len(a.Deps)}
+	}
+	for _, a := range s.actions {
+		for _, dep := range a.Deps {
+			dependents[dep] = append(dependents[dep], a.ID)
+		}
+	}
+
+	var mu sync.Mutex
+	cond := sync.NewCond(&mu)
+	usedWeight := 0
+	pending := len(nodes)
+	var errs []error
+	var ready []string
+	for id, n := range nodes {
+		if n.remaining == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	mu.Lock()
+	for pending > 0 {
+		for i := 0; i < len(ready); {
+			id := ready[i]
+			a := nodes[id].action
+
+			// Always admit at least one action even if its weight alone
+			// exceeds the budget, or a single heavy action would starve.
+			if usedWeight > 0 && usedWeight+a.Weight > s.Budget {
+				i++
+				continue
+			}
+
+			ready = append(ready[:i], ready[i+1:]...)
+			usedWeight += a.Weight
+
+			go func(id string, a *Action) {
+				err := a.Run()
+
+				mu.Lock()
+				usedWeight -= a.Weight
+				pending--
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %s", id, err))
+				}
+				for _, depID := range dependents[id] {
+					dn := nodes[depID]
+					dn.remaining--
+					if dn.remaining == 0 {
+						ready = append(ready, depID)
+					}
+				}
+				mu.Unlock()
+				cond.Broadcast()
+			}(id, a)
+		}
+
+		if pending > 0 {
+			cond.Wait()
+		}
+	}
+	mu.Unlock()
+
+	if len(errs) > 0 {
+		return &MultiError{Errors: errs}
+	}
+
+	return nil
+}
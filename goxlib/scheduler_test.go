@@ -0,0 +1,103 @@
+package goxlib
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestScheduler_RunsDependenciesFirst(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+
+	s := NewScheduler(2)
+	s.Add(&Action{
+		ID:     "analyze",
+		Weight: 1,
+		Run: func() error {
+			mu.Lock()
+			order = append(order, "analyze")
+			mu.Unlock()
+			return nil
+		},
+	})
+	s.Add(&Action{
+		ID:     "compile",
+		Weight: 2,
+		Deps:   []string{"analyze"},
+		Run: func() error {
+			mu.Lock()
+			order = append(order, "compile")
+			mu.Unlock()
+			return nil
+		},
+	})
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(order) != 2 || order[0] != "analyze" || order[1] != "compile" {
+		t.Fatalf("got %v, want [analyze compile]", order)
+	}
+}
+
+func TestScheduler_RespectsWeightBudget(t *testing.T) {
+	var inFlight, maxInFlight int32
+
+	s := NewScheduler(2)
+	for i := 0; i < 5; i++ {
+		s.Add(&Action{
+			ID:     string(rune('a' + i)),
+			Weight: 2,
+			Run: func() error {
+				n := atomic.AddInt32(&inFlight, 1)
+				for {
+					cur := atomic.LoadInt32(&maxInFlight)
+					if n <= cur || atomic.CompareAndSwapInt32(&maxInFlight, cur, n) {
+						break
+					}
+				}
+				atomic.AddInt32(&inFlight, -1)
+				return nil
+			},
+		})
+	}
+
+	if err := s.Run(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if maxInFlight > 1 {
+		t.Fatalf("expected at most 1 weight-2 action in flight under a budget of 2, got %d", maxInFlight)
+	}
+}
+
+func TestScheduler_FailureUnblocksDependents(t *testing.T) {
+	ran := false
+
+	s := NewScheduler(1)
+	s.Add(&Action{
+		ID:     "analyze",
+		Weight: 1,
+		Run:    func() error { return errors.New("analysis failed") },
+	})
+	s.Add(&Action{
+		ID:     "compile",
+		Weight: 1,
+		Deps:   []string{"analyze"},
+		Run: func() error {
+			ran = true
+			return nil
+		},
+	})
+
+	err := s.Run()
+	if err == nil {
+		t.Fatalf("expected an aggregated error")
+	}
+	if !ran {
+		t.Fatalf("expected the dependent action to still run after its dependency failed")
+	}
+}
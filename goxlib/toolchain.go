@@ -13,7 +13,7 @@ import (
 )
 
 // The "main" method for when the toolchain build is requested.
-func mainBuildToolchain(parallel int, platformFlag PlatformFlag, verbose bool) error {
+func mainBuildToolchain(parallel int, platformFlag PlatformFlag, verbose bool, opts ToolchainOpts) error {
 	if _, err := exec.LookPath("go"); err != nil {
 		fmt.Fprint(os.Stderr, "You must have Go already built for your native platform\n")
 		fmt.Fprint(os.Stderr, "and the `go` binary on the PATH to build toolchains.\n")
@@ -46,13 +46,27 @@ func mainBuildToolchain(parallel int, platformFlag PlatformFlag, verbose bool) e
 		return err
 	}
 
+	if opts.TargetOnly {
+		if err := checkBootstrapToolchain(root); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return err
+		}
+	}
+
 	if verbose {
 		fmt.Println("Verbose mode enabled. Output from building each toolchain will be")
 		fmt.Println("outputted to stdout as they are built.\n")
 	}
 
-	// Determine the platforms we're building the toolchain for.
-	platforms := platformFlag.Platforms(SupportedPlatforms(version))
+	// Determine the platforms we're building the toolchain for. In
+	// -host-only mode we're only ever building the native bootstrap, so
+	// the platform flags don't apply.
+	var platforms []Platform
+	if opts.HostOnly {
+		platforms = []Platform{{OS: runtime.GOOS, Arch: runtime.GOARCH}}
+	} else {
+		platforms = platformFlag.Platforms(SupportedPlatforms(version))
+	}
 
 	// The toolchain build can't be parallelized.
 	if parallel > 1 {
@@ -69,7 +83,7 @@ func mainBuildToolchain(parallel int, platformFlag PlatformFlag, verbose bool) e
 	for _, platform := range platforms {
 		wg.Add(1)
 		go func(platform Platform) {
-			err := BuildToolchain(&wg, semaphore, root, platform, verbose)
+			err := BuildToolchain(&wg, semaphore, root, platform, verbose, opts)
 			if err != nil {
 				errorLock.Lock()
 				defer errorLock.Unlock()
@@ -84,13 +98,35 @@ func mainBuildToolchain(parallel int, platformFlag PlatformFlag, verbose bool) e
 		for _, err := range errs {
 			fmt.Fprintf(os.Stderr, "%s\n", err)
 		}
-		return err
+		return &MultiError{Errors: errs}
 	}
 
 	return nil
 }
 
-func BuildToolchain(wg *sync.WaitGroup, semaphore chan int, root string, platform Platform, verbose bool) error {
+// checkBootstrapToolchain verifies that a host bootstrap toolchain built by
+// a prior -host-only -keep-bootstrap run is present under root, which is
+// where -target-only expects to find and reuse it. -host-only/-target-only
+// and GOX_KEEP_BOOTSTRAP are gox-specific extensions: they only work
+// against a patched make.bash/make.bat that understands them, not stock
+// upstream Go, so without this check a missing or unpatched bootstrap
+// would otherwise surface as a confusing make.bash failure instead of a
+// clear error naming the missing directory.
+func checkBootstrapToolchain(root string) error {
+	bootstrapDir := filepath.Join(root, "pkg", "tool", "native_native")
+	info, err := os.Stat(bootstrapDir)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf(
+			"-target-only requires a host bootstrap toolchain at %s, built by a "+
+				"prior `-build-toolchain -host-only -keep-bootstrap` run against a "+
+				"make.bash/make.bat patched to support these gox-specific flags; "+
+				"none was found",
+			bootstrapDir)
+	}
+	return nil
+}
+
+func BuildToolchain(wg *sync.WaitGroup, semaphore chan int, root string, platform Platform, verbose bool, opts ToolchainOpts) error {
 	defer wg.Done()
 	semaphore <- 1
 	defer func() { <-semaphore }()
@@ -105,11 +141,18 @@ func BuildToolchain(wg *sync.WaitGroup, semaphore chan int, root string, platfor
 	var stdout bytes.Buffer
 	scriptDir := filepath.Join(root, "src")
 	scriptPath := filepath.Join(scriptDir, scriptName)
-	cmd := exec.Command(scriptPath, "--no-clean")
+	cmd := exec.Command(scriptPath, opts.args()...)
 	cmd.Dir = scriptDir
-	cmd.Env = append(os.Environ(),
+	env := append(os.Environ(),
 		"GOARCH="+platform.Arch,
 		"GOOS="+platform.OS)
+	if opts.HostOnly && opts.KeepBootstrap {
+		// The bootstrap toolchain lands in pkg/tool/native_native; tell
+		// make.bash not to clean it up so a later -target-only run can
+		// reuse it instead of rebuilding the host toolchain.
+		env = append(env, "GOX_KEEP_BOOTSTRAP=1")
+	}
+	cmd.Env = env
 	cmd.Stderr = &stderr
 	cmd.Stdout = &stdout
 
@@ -0,0 +1,44 @@
+package goxlib
+
+// ToolchainOpts controls how -build-toolchain drives the Go distribution's
+// make.bash/make.bat, so that a host bootstrap can be built once and reused
+// across many target builds (e.g. by a distro build system doing a
+// cross-canadian build).
+//
+// --host-only/--target-only are not understood by stock upstream Go's
+// make.bash/make.bat; HostOnly and TargetOnly only work against a
+// make.bash/make.bat patched to recognize them and to honor
+// GOX_KEEP_BOOTSTRAP. mainBuildToolchain checks for a cached bootstrap
+// before a TargetOnly run, but that check can't detect an unpatched
+// script — the script itself will simply fail on the unrecognized flag.
+type ToolchainOpts struct {
+	// HostOnly builds and retains the native go_bootstrap and supporting
+	// toolchain binaries (asm, compile, link, etc.) under
+	// pkg/tool/native_native instead of also cross-compiling for Platform.
+	HostOnly bool
+
+	// TargetOnly skips the host bootstrap build and reuses the bootstrap
+	// left behind by a prior -host-only run to produce the
+	// pkg/tool/${goos}_${goarch} tree for Platform.
+	TargetOnly bool
+
+	// KeepBootstrap prevents make.bash/make.bat from removing the
+	// bootstrap toolchain at the end of a -host-only build.
+	KeepBootstrap bool
+
+	// Packages, if non-empty, is forwarded to make.bash/make.bat to
+	// restrict which packages are built.
+	Packages []string
+}
+
+// args builds the make.bash/make.bat argument list for these options.
+func (o ToolchainOpts) args() []string {
+	args := []string{"--no-clean"}
+	if o.HostOnly {
+		args = append(args, "--host-only")
+	}
+	if o.TargetOnly {
+		args = append(args, "--target-only")
+	}
+	return append(args, o.Packages...)
+}
@@ -0,0 +1,23 @@
+package goxlib
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckBootstrapToolchain(t *testing.T) {
+	root := t.TempDir()
+	if err := checkBootstrapToolchain(root); err == nil {
+		t.Fatalf("expected an error when no bootstrap toolchain is present")
+	}
+
+	bootstrapDir := filepath.Join(root, "pkg", "tool", "native_native")
+	if err := os.MkdirAll(bootstrapDir, 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := checkBootstrapToolchain(root); err != nil {
+		t.Fatalf("expected no error once the bootstrap toolchain exists, got: %s", err)
+	}
+}
@@ -9,6 +9,17 @@ import (
 func main() {
 	if _, err := goxlib.CrossCompile(); err != nil {
 		fmt.Println(err)
+
+		if merr, ok := err.(*goxlib.MultiError); ok {
+			// Clamp to the POSIX exit status range; os.Exit truncates to
+			// the low 8 bits, and an unclamped count that happens to be a
+			// multiple of 256 would wrap around to a misleading 0.
+			code := len(merr.Errors)
+			if code > 255 {
+				code = 255
+			}
+			os.Exit(code)
+		}
 		os.Exit(1)
 	}
 }